@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+	"github.com/votopreferencial/zk/census"
+)
+
+// RegisterCensus publishes a new census commitment: root is the Merkle
+// root produced by census.Builder over the eligible voter roll, and size
+// is the number of real (non-padding) entries it commits to. CastVote then
+// requires a census.Proof against root instead of scanning an on-chain
+// electors list; CastAnonymousVote takes no census.Proof and instead uses
+// root as the CensusRoot public input of its zk proof. Re-calling RegisterCensus rotates the
+// census — e.g. to correct the roll — by republishing a root, without
+// touching any other election state. Only allowed before the election
+// opens, since admitting or revoking voters mid-election would invalidate
+// ballots already cast under the old root.
+func (s *SmartContract) RegisterCensus(ctx contractapi.TransactionContextInterface, root []byte, size int) error {
+	election, err := readElection(ctx)
+	if err != nil {
+		return err
+	}
+	if election.State != StateDraft {
+		return fmt.Errorf("census can only be registered while the election is in the Draft state, got %s", election.State)
+	}
+	if len(root) == 0 {
+		return fmt.Errorf("census root must not be empty")
+	}
+
+	election.CensusRoot = root
+	election.CensusSize = size
+	return putElection(ctx, election)
+}
+
+// verifyCensusMembership checks that proof admits voter under e's
+// registered CensusRoot, binding the proof's key to the caller's claimed
+// identity so a valid proof for one voter cannot be replayed for another.
+func verifyCensusMembership(e *Election, voter string, proof census.Proof) error {
+	if string(proof.Key) != voter {
+		return fmt.Errorf("census proof key does not match voter %q", voter)
+	}
+	if !census.Verify(e.CensusRoot, proof) {
+		return fmt.Errorf("census proof does not verify against the registered census root")
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+// Package census builds and verifies the Merkle tree used to gate voter
+// eligibility: organizers run Builder off-chain over the electors roll and
+// publish only the resulting root on-chain (via RegisterCensus), while
+// voters present a Proof from the same tree to CastVote. This keeps the
+// roll itself, which may be large or privacy-sensitive, off the ledger.
+package census
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"sort"
+)
+
+// emptyLeaf pads a census tree out to a power of two so every proof has a
+// uniform depth, without admitting any real voter.
+var emptyLeaf = sha256.Sum256([]byte("votopreferencial/zk census empty leaf"))
+
+// Leaf hashes a voter's key together with an opaque value (e.g. a weight
+// or role tag; nil for a plain allow-list) into the tree's leaf encoding.
+func Leaf(key, value []byte) []byte {
+	h := sha256.Sum256(append(append([]byte{}, key...), value...))
+	return h[:]
+}
+
+func hashPair(left, right []byte) []byte {
+	h := sha256.Sum256(append(append([]byte{}, left...), right...))
+	return h[:]
+}
+
+// Step is one sibling hash on the path from a leaf to the census root.
+type Step struct {
+	Hash []byte `json:"hash"`
+	Left bool   `json:"left"`
+}
+
+// Proof is what a voter presents to CastVote to show their (Key, Value)
+// pair is admitted by the election's registered census root.
+type Proof struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+	Steps []Step `json:"steps"`
+}
+
+// Verify recomputes the root implied by proof and reports whether it
+// matches root.
+func Verify(root []byte, proof Proof) bool {
+	if len(root) == 0 {
+		return false
+	}
+	acc := Leaf(proof.Key, proof.Value)
+	for _, step := range proof.Steps {
+		if step.Left {
+			acc = hashPair(step.Hash, acc)
+		} else {
+			acc = hashPair(acc, step.Hash)
+		}
+	}
+	return bytes.Equal(acc, root)
+}
+
+type entry struct {
+	key, value []byte
+}
+
+// Builder accumulates voter (key, value) pairs and builds a binary Merkle
+// census tree over them, sorted by key so the resulting root is
+// independent of registration order.
+type Builder struct {
+	entries []entry
+}
+
+// NewBuilder returns an empty census builder.
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// Add registers a voter's key with an opaque value (pass nil for a plain
+// allow-list).
+func (b *Builder) Add(key, value []byte) {
+	b.entries = append(b.entries, entry{key: key, value: value})
+}
+
+// sortedLeaves returns the builder's entries sorted by key, and the
+// corresponding leaf hashes padded with emptyLeaf up to the next power of
+// two.
+func (b *Builder) sortedLeaves() ([]entry, [][]byte) {
+	entries := append([]entry{}, b.entries...)
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].key, entries[j].key) < 0
+	})
+
+	size := 1
+	for size < len(entries) {
+		size *= 2
+	}
+
+	leaves := make([][]byte, size)
+	for i, e := range entries {
+		leaves[i] = Leaf(e.key, e.value)
+	}
+	for i := len(entries); i < size; i++ {
+		leaves[i] = emptyLeaf[:]
+	}
+	return entries, leaves
+}
+
+func merkleRoot(leaves [][]byte) []byte {
+	if len(leaves) == 1 {
+		return leaves[0]
+	}
+	mid := len(leaves) / 2
+	return hashPair(merkleRoot(leaves[:mid]), merkleRoot(leaves[mid:]))
+}
+
+func merkleProof(leaves [][]byte, target int) []Step {
+	if len(leaves) == 1 {
+		return nil
+	}
+	mid := len(leaves) / 2
+	if target < mid {
+		steps := merkleProof(leaves[:mid], target)
+		return append(steps, Step{Hash: merkleRoot(leaves[mid:]), Left: false})
+	}
+	steps := merkleProof(leaves[mid:], target-mid)
+	return append(steps, Step{Hash: merkleRoot(leaves[:mid]), Left: true})
+}
+
+// Root returns the census root and the number of real (non-padding)
+// voters registered, for use with RegisterCensus.
+func (b *Builder) Root() ([]byte, int) {
+	_, leaves := b.sortedLeaves()
+	if len(leaves) == 0 {
+		return nil, 0
+	}
+	return merkleRoot(leaves), len(b.entries)
+}
+
+// Proof returns a membership proof for (key, value), or false if that
+// pair was never added to the builder.
+func (b *Builder) Proof(key, value []byte) (*Proof, bool) {
+	entries, leaves := b.sortedLeaves()
+	for i, e := range entries {
+		if bytes.Equal(e.key, key) && bytes.Equal(e.value, value) {
+			return &Proof{Key: key, Value: value, Steps: merkleProof(leaves, i)}, true
+		}
+	}
+	return nil, false
+}
@@ -1,152 +1,334 @@
-package main
-
-import (
-	encodingjson
-	fmt
-	strconv
-
-	github.comhyperledgerfabric-contract-api-gocontractapi
-)
-
-type SmartContract struct {
-	contractapi.Contract
-}
-
-type Candidate struct {
-	Name      string `jsonname`
-	VoteCount int    `jsonvoteCount`
-}
-
-type Ballot struct {
-	Voter      string   `jsonvoter`
-	Preferences []int    `jsonpreferences`
-	Voted      bool     `jsonvoted`
-}
-
-type Election struct {
-	Candidates []Candidate `jsoncandidates`
-	Seats      int         `jsonseats`
-	TotalVotes int         `jsontotalVotes`
-	Ballots    []Ballot    `jsonballots`
-}
-
-func (s SmartContract) InitElection(ctx contractapi.TransactionContextInterface, candidateNames []string, seats int) error {
-	var candidates []Candidate
-	for _, name = range candidateNames {
-		candidates = append(candidates, Candidate{Name name, VoteCount 0})
-	}
-	election = Election{Candidates candidates, Seats seats, TotalVotes 0, Ballots []Ballot{}}
-
-	electionJSON, err = json.Marshal(election)
-	if err != nil {
-		return err
-	}
-
-	return ctx.GetStub().PutState(election, electionJSON)
-}
-
-func (s SmartContract) CastVote(ctx contractapi.TransactionContextInterface, voter string, preferences []int) error {
-	electionJSON, err = ctx.GetStub().GetState(election)
-	if err != nil {
-		return err
-	}
-	if electionJSON == nil {
-		return fmt.Errorf(election does not exist)
-	}
-
-	var election Election
-	err = json.Unmarshal(electionJSON, &election)
-	if err != nil {
-		return err
-	}
-
-	for _, b = range election.Ballots {
-		if b.Voter == voter {
-			return fmt.Errorf(voter has already voted)
-		}
-	}
-
-	ballot = Ballot{
-		Voter      voter,
-		Preferences preferences,
-		Voted      true,
-	}
-	election.Ballots = append(election.Ballots, ballot)
-	election.TotalVotes++
-
-	electionJSON, err = json.Marshal(election)
-	if err != nil {
-		return err
-	}
-
-	return ctx.GetStub().PutState(election, electionJSON)
-}
-
-func (s SmartContract) CountVotes(ctx contractapi.TransactionContextInterface) error {
-	electionJSON, err = ctx.GetStub().GetState(election)
-	if err != nil {
-		return err
-	}
-	if electionJSON == nil {
-		return fmt.Errorf(election does not exist)
-	}
-
-	var election Election
-	err = json.Unmarshal(electionJSON, &election)
-	if err != nil {
-		return err
-	}
-
-	quota = election.TotalVotes  (election.Seats + 1)
-
-	for seatsRemaining = election.Seats; seatsRemaining  0; {
-		for _, ballot = range election.Ballots {
-			firstPref = ballot.Preferences[0]
-			election.Candidates[firstPref].VoteCount++
-		}
-
-		for i, candidate = range election.Candidates {
-			if candidate.VoteCount = quota {
-				seatsRemaining--
-				candidate.VoteCount = 0
-				election.Candidates[i] = candidate
-			}
-		}
-	}
-
-	electionJSON, err = json.Marshal(election)
-	if err != nil {
-		return err
-	}
-
-	return ctx.GetStub().PutState(election, electionJSON)
-}
-
-func (s SmartContract) GetElectionResults(ctx contractapi.TransactionContextInterface) ([]Candidate, error) {
-	electionJSON, err = ctx.GetStub().GetState(election)
-	if err != nil {
-		return nil, err
-	}
-	if electionJSON == nil {
-		return nil, fmt.Errorf(election does not exist)
-	}
-
-	var election Election
-	err = json.Unmarshal(electionJSON, &election)
-	if err != nil {
-		return nil, err
-	}
-
-	return election.Candidates, nil
-}
-
-func main() {
-	chaincode, err = contractapi.NewChaincode(new(SmartContract))
-	if err != nil {
-		fmt.Printf(Error creating chaincode %s, err)
-	}
-
-	if err = chaincode.Start(); err != nil {
-		fmt.Printf(Error starting chaincode %s, err)
-	}
-}
\ No newline at end of file
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+	"github.com/votopreferencial/zk/census"
+)
+
+// electionKey is the world-state key under which the single Election
+// document tracked by this chaincode is stored.
+const electionKey = "election"
+
+// Tally methods supported by CountVotes.
+const (
+	TallySTV   = "stv"
+	TallyBorda = "borda"
+)
+
+type SmartContract struct {
+	contractapi.Contract
+}
+
+type Candidate struct {
+	Name      string `json:"name"`
+	VoteCount int    `json:"voteCount"`
+}
+
+type Ballot struct {
+	Voter       string `json:"voter"`
+	Preferences []int  `json:"preferences"`
+	Voted       bool   `json:"voted"`
+}
+
+// RoundResult is one round of an STV count, kept so GetElectionResults can
+// return a full audit trail instead of just the final seats.
+type RoundResult struct {
+	Round          int       `json:"round"`
+	Tallies        []float64 `json:"tallies"`
+	Elected        []int     `json:"elected,omitempty"`
+	Eliminated     int       `json:"eliminated"`
+	HasElimination bool      `json:"hasElimination"`
+}
+
+type Election struct {
+	ID           string        `json:"id"`
+	Candidates   []Candidate   `json:"candidates"`
+	Seats        int           `json:"seats"`
+	TotalVotes   int           `json:"totalVotes"`
+	TallyMethod  string        `json:"tallyMethod"`
+	BordaWeights []int         `json:"bordaWeights,omitempty"`
+	Rounds       []RoundResult `json:"rounds,omitempty"`
+	Elected      []int         `json:"elected,omitempty"`
+	Tallied      bool          `json:"tallied"`
+
+	// Ballots are not stored here: each CastVote appends to a dedicated
+	// world-state key instead of rewriting a growing array. These three
+	// fields are the append-only log's bookkeeping; see ledger.go.
+	BallotSeq   int      `json:"ballotSeq"`
+	BallotsRoot []byte   `json:"ballotsRoot,omitempty"`
+	BallotPeaks [][]byte `json:"ballotPeaks,omitempty"`
+
+	// Lifecycle fields; see lifecycle.go for the state machine and
+	// quorum/threshold evaluation.
+	State     ElectionState `json:"state"`
+	Deadline  int64         `json:"deadline,omitempty"`
+	Quorum    string        `json:"quorum,omitempty"`
+	Threshold int           `json:"threshold,omitempty"`
+
+	// Census fields gate voter eligibility: when CensusRoot is set,
+	// CastVote requires a census.Proof against it instead of an
+	// open-to-everyone ballot. CensusSize is the electorate used by
+	// evaluateQuorum. See RegisterCensus and the census/ package.
+	CensusRoot []byte `json:"censusRoot,omitempty"`
+	CensusSize int    `json:"censusSize,omitempty"`
+
+	// Anonymous-voting fields; only populated when Anonymous is true. See
+	// CastAnonymousVote and TallyDecrypt in zk.go.
+	Anonymous        bool              `json:"anonymous,omitempty"`
+	VerifyingKey     []byte            `json:"verifyingKey,omitempty"`
+	Trustees         []string          `json:"trustees,omitempty"`
+	Nullifiers       map[string]bool   `json:"nullifiers,omitempty"`
+	EncryptedBallots []EncryptedBallot `json:"encryptedBallots,omitempty"`
+}
+
+// EncryptedBallot is an anonymous ballot as submitted by CastAnonymousVote:
+// the plaintext preferences are only recovered once TallyDecrypt collects
+// enough trustee shares to reconstruct them.
+type EncryptedBallot struct {
+	Nullifier  string `json:"nullifier"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// ElectionResults is the public audit trail returned to clients: the final
+// seat allocation plus, for STV, the round-by-round detail that produced it.
+type ElectionResults struct {
+	Candidates  []Candidate   `json:"candidates"`
+	TallyMethod string        `json:"tallyMethod"`
+	Elected     []int         `json:"elected,omitempty"`
+	Rounds      []RoundResult `json:"rounds,omitempty"`
+}
+
+// InitElection creates the election document. tallyMethod selects the
+// counting algorithm used by CountVotes ("stv" or "borda"); it defaults to
+// "stv" when empty. bordaWeights is only consulted when tallyMethod is
+// "borda" and gives the per-rank weight awarded to a ballot's 1st, 2nd, ...
+// preference.
+//
+// anonymous switches the election to the zk-SNARK voting mode implemented
+// in zk.go: censusRoot is the Merkle root of eligible-voter commitments,
+// verifyingKey is the groth16 verifying key for circuits.BallotCircuit,
+// and trustees are the parties who must later submit shares to
+// TallyDecrypt before the real preferences can be reconstructed.
+//
+// deadline is a unix timestamp after which CastVote stops accepting
+// ballots (0 = no deadline). quorum is "SIMPLE", "TWO_THIRDS", or an
+// absolute ballot count. "SIMPLE" and "TWO_THIRDS" are evaluated against
+// the registered census size and always clear when no census is
+// registered; an absolute count is compared with the number of ballots
+// cast, census or not (see evaluateQuorum). threshold is the minimum
+// tally a candidate must reach for CountVotes to award them a seat. The
+// election starts in the Draft state; see lifecycle.go.
+//
+// censusRoot and censusSize seed the election's census commitment exactly
+// as RegisterCensus would; pass a nil root to leave the census
+// unregistered and add it later with RegisterCensus. Anonymous elections
+// must pass a root here: InitElection rejects an empty censusRoot when
+// anonymous is true, since the zk circuit binds CensusRoot at proof time.
+// See the census/ package.
+func (s *SmartContract) InitElection(ctx contractapi.TransactionContextInterface, candidateNames []string, seats int, tallyMethod string, bordaWeights []int, anonymous bool, censusRoot []byte, censusSize int, verifyingKey []byte, trustees []string, deadline int64, quorum string, threshold int) error {
+	if seats <= 0 {
+		return fmt.Errorf("seats must be positive")
+	}
+	if tallyMethod == "" {
+		tallyMethod = TallySTV
+	}
+	if tallyMethod != TallySTV && tallyMethod != TallyBorda {
+		return fmt.Errorf("unknown tally method %q", tallyMethod)
+	}
+	if anonymous {
+		if len(censusRoot) == 0 {
+			return fmt.Errorf("anonymous election requires a census_root")
+		}
+		if len(verifyingKey) == 0 {
+			return fmt.Errorf("anonymous election requires a verifying key")
+		}
+		if len(trustees) == 0 {
+			return fmt.Errorf("anonymous election requires at least one trustee")
+		}
+	}
+
+	var candidates []Candidate
+	for _, name := range candidateNames {
+		candidates = append(candidates, Candidate{Name: name, VoteCount: 0})
+	}
+
+	election := Election{
+		ID:           electionKey,
+		Candidates:   candidates,
+		Seats:        seats,
+		TotalVotes:   0,
+		TallyMethod:  tallyMethod,
+		BordaWeights: bordaWeights,
+		Anonymous:    anonymous,
+		CensusRoot:   censusRoot,
+		CensusSize:   censusSize,
+		VerifyingKey: verifyingKey,
+		Trustees:     trustees,
+		Nullifiers:   map[string]bool{},
+		State:        StateDraft,
+		Deadline:     deadline,
+		Quorum:       quorum,
+		Threshold:    threshold,
+	}
+
+	electionJSON, err := json.Marshal(election)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().PutState(electionKey, electionJSON)
+}
+
+// CastVote records voter's ranked preferences. When the election has a
+// registered census (see RegisterCensus), proof must be a census.Proof
+// admitting voter under Election.CensusRoot; pass the zero value when no
+// census has been registered, in which case voting is open to anyone.
+func (s *SmartContract) CastVote(ctx contractapi.TransactionContextInterface, voter string, preferences []int, proof census.Proof) error {
+	election, err := readElection(ctx)
+	if err != nil {
+		return err
+	}
+	if election.Anonymous {
+		return fmt.Errorf("this election only accepts anonymous votes, use CastAnonymousVote")
+	}
+	if election.State != StateOpen {
+		return fmt.Errorf("election is not open for voting (state: %s)", election.State)
+	}
+	if election.Deadline > 0 {
+		past, err := deadlineHasPassed(ctx, election.Deadline)
+		if err != nil {
+			return err
+		}
+		if past {
+			return fmt.Errorf("voting deadline has passed")
+		}
+	}
+	if len(election.CensusRoot) > 0 {
+		if err := verifyCensusMembership(&election, voter, proof); err != nil {
+			return err
+		}
+	}
+	for _, p := range preferences {
+		if p < 0 || p >= len(election.Candidates) {
+			return fmt.Errorf("preference %d is not a valid candidate index", p)
+		}
+	}
+
+	if _, err := appendBallot(ctx, &election, voter, preferences); err != nil {
+		return err
+	}
+	election.TotalVotes++
+
+	if err := putElection(ctx, election); err != nil {
+		return err
+	}
+	return emitCastVote(ctx, voter, election.TotalVotes)
+}
+
+// CountVotes runs the election's configured tally method over the cast
+// ballots and persists the result (elected seats, and for STV the
+// round-by-round audit trail) onto the Election document. It only runs
+// once the election has been moved to the Tallying state (see
+// CloseElection in lifecycle.go), and awards no seats at all when
+// participation falls below Quorum, or a seat to any candidate whose
+// tally falls below Threshold.
+func (s *SmartContract) CountVotes(ctx contractapi.TransactionContextInterface) error {
+	election, err := readElection(ctx)
+	if err != nil {
+		return err
+	}
+	if election.Anonymous {
+		return fmt.Errorf("this election uses anonymous ballots; call TallyDecrypt instead")
+	}
+	if election.State != StateTallying {
+		return fmt.Errorf("election must be in the Tallying state to be counted, got %s", election.State)
+	}
+
+	if _, quorumMet := evaluateQuorum(&election); quorumMet {
+		ballots, err := loadBallots(ctx, election.ID)
+		if err != nil {
+			return err
+		}
+
+		switch election.TallyMethod {
+		case TallyBorda:
+			election.Elected, err = runBorda(&election, ballots)
+		case TallySTV, "":
+			election.Rounds, election.Elected, err = runSTV(&election, ballots)
+		default:
+			err = fmt.Errorf("unknown tally method %q", election.TallyMethod)
+		}
+		if err != nil {
+			return err
+		}
+		applyThreshold(&election)
+	} else {
+		election.Elected = nil
+	}
+
+	election.Tallied = true
+	election.State = StateClosed
+
+	if err := putElection(ctx, election); err != nil {
+		return err
+	}
+	return emitCountVotes(ctx, election.Elected)
+}
+
+func (s *SmartContract) GetElectionResults(ctx contractapi.TransactionContextInterface) (*ElectionResults, error) {
+	election, err := readElection(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !election.Tallied {
+		return nil, fmt.Errorf("votes have not been counted yet")
+	}
+
+	return &ElectionResults{
+		Candidates:  election.Candidates,
+		TallyMethod: election.TallyMethod,
+		Elected:     election.Elected,
+		Rounds:      election.Rounds,
+	}, nil
+}
+
+func readElection(ctx contractapi.TransactionContextInterface) (Election, error) {
+	var election Election
+
+	electionJSON, err := ctx.GetStub().GetState(electionKey)
+	if err != nil {
+		return election, err
+	}
+	if electionJSON == nil {
+		return election, fmt.Errorf("election does not exist")
+	}
+
+	if err := json.Unmarshal(electionJSON, &election); err != nil {
+		return election, err
+	}
+	return election, nil
+}
+
+func putElection(ctx contractapi.TransactionContextInterface, election Election) error {
+	electionJSON, err := json.Marshal(election)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(electionKey, electionJSON)
+}
+
+func main() {
+	chaincode, err := contractapi.NewChaincode(&SmartContract{})
+	if err != nil {
+		fmt.Printf("Error creating chaincode: %s", err)
+		return
+	}
+
+	if err := chaincode.Start(); err != nil {
+		fmt.Printf("Error starting chaincode: %s", err)
+	}
+}
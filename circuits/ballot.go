@@ -0,0 +1,159 @@
+// Package circuits holds the gnark circuits used to prove anonymous
+// ballots are well-formed without revealing who cast them.
+package circuits
+
+import (
+	"math/big"
+
+	gnarkmimc "github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/std/hash/poseidon"
+)
+
+// MerkleDepth bounds the size of the eligible-voter census this circuit can
+// prove membership against (up to 2^MerkleDepth voters).
+const MerkleDepth = 20
+
+// MaxPreferences is the longest ballot (number of ranked candidates) the
+// circuit can prove a valid permutation for.
+const MaxPreferences = 16
+
+// MaxCiphertextChunks bounds how many field-sized chunks of the encrypted
+// ballot (ciphertextPreferences) the circuit will bind a proof to; see
+// CiphertextCommitment and ChunkCiphertext.
+const MaxCiphertextChunks = 64
+
+// ciphertextChunkSize is the number of bytes per chunk: comfortably under
+// the ~31.75 bytes a BN254 scalar field element can hold.
+const ciphertextChunkSize = 31
+
+// BallotCircuit proves, for a single anonymous ballot, that the prover:
+//
+//  1. knows a secret whose Poseidon hash is a leaf under CensusRoot
+//     (Merkle membership in the eligible-voter census);
+//  2. derives Nullifier = Poseidon(Secret, ElectionID), binding the ballot
+//     to the voter without revealing which leaf they are;
+//  3. ranked a valid permutation of a subset of candidate indices: no
+//     duplicates, and every ranked index lies in [0, NumCandidates); and
+//  4. knows the exact ciphertextPreferences blob submitted on-chain
+//     alongside this proof, via CiphertextCommitment (see
+//     assertCiphertextCommitment) — without this, a proof generated for
+//     one ballot could be replayed with a different, unrelated ciphertext.
+//
+// Preferences/PreferenceLen are the private witness for the plaintext the
+// ciphertext encrypts; the circuit does not itself perform the encryption,
+// only attests that the plaintext it commits to is well-formed and that
+// the ciphertext committed to is the one actually submitted.
+type BallotCircuit struct {
+	// Public inputs.
+	CensusRoot           frontend.Variable `gnark:",public"`
+	ElectionID           frontend.Variable `gnark:",public"`
+	Nullifier            frontend.Variable `gnark:",public"`
+	NumCandidates        frontend.Variable `gnark:",public"`
+	CiphertextCommitment frontend.Variable `gnark:",public"`
+
+	// Private witness.
+	Secret           frontend.Variable
+	PathElements     [MerkleDepth]frontend.Variable
+	PathIndices      [MerkleDepth]frontend.Variable
+	Preferences      [MaxPreferences]frontend.Variable
+	PreferenceLen    frontend.Variable
+	CiphertextChunks [MaxCiphertextChunks]frontend.Variable
+}
+
+func (c *BallotCircuit) Define(api frontend.API) error {
+	c.assertCensusMembership(api)
+	c.assertNullifier(api)
+	c.assertValidPermutation(api)
+	return c.assertCiphertextCommitment(api)
+}
+
+func (c *BallotCircuit) assertCensusMembership(api frontend.API) {
+	node := poseidon.Poseidon(api, c.Secret)
+	for i := 0; i < MerkleDepth; i++ {
+		left := api.Select(c.PathIndices[i], c.PathElements[i], node)
+		right := api.Select(c.PathIndices[i], node, c.PathElements[i])
+		node = poseidon.Poseidon(api, left, right)
+	}
+	api.AssertIsEqual(node, c.CensusRoot)
+}
+
+func (c *BallotCircuit) assertNullifier(api frontend.API) {
+	api.AssertIsEqual(c.Nullifier, poseidon.Poseidon(api, c.Secret, c.ElectionID))
+}
+
+// assertValidPermutation rejects out-of-range candidate indices and, among
+// the slots the ballot actually uses (index < PreferenceLen), any repeated
+// candidate.
+func (c *BallotCircuit) assertValidPermutation(api frontend.API) {
+	for i := 0; i < MaxPreferences; i++ {
+		active := cmpLess(api, i, c.PreferenceLen)
+		api.AssertIsLessOrEqual(c.Preferences[i], api.Sub(c.NumCandidates, 1))
+
+		for j := i + 1; j < MaxPreferences; j++ {
+			activeJ := cmpLess(api, j, c.PreferenceLen)
+			bothActive := api.Mul(active, activeJ)
+			api.AssertIsDifferent(api.Select(bothActive, c.Preferences[i], -1), api.Select(bothActive, c.Preferences[j], -2))
+		}
+	}
+}
+
+// cmpLess returns 1 if slot < length, 0 otherwise.
+func cmpLess(api frontend.API, slot int, length frontend.Variable) frontend.Variable {
+	return api.IsZero(api.Add(api.Cmp(frontend.Variable(slot), length), 1))
+}
+
+// assertCiphertextCommitment binds the proof to one specific ciphertext:
+// CiphertextCommitment must be the MiMC hash of CiphertextChunks, the
+// private, field-chunked encoding of the exact ciphertextPreferences bytes
+// CastAnonymousVote is called with. verifyBallotProof computes the same
+// commitment natively from those bytes (see CommitCiphertext) before
+// verifying, so a proof whose chunks don't hash to the submitted
+// ciphertext's commitment is rejected.
+func (c *BallotCircuit) assertCiphertextCommitment(api frontend.API) error {
+	h, err := mimc.NewMiMC(api)
+	if err != nil {
+		return err
+	}
+	for _, chunk := range c.CiphertextChunks {
+		h.Write(chunk)
+	}
+	api.AssertIsEqual(h.Sum(), c.CiphertextCommitment)
+	return nil
+}
+
+// ChunkCiphertext splits ciphertext into MaxCiphertextChunks big-endian,
+// ciphertextChunkSize-byte field elements, zero-padded, matching the
+// encoding CiphertextChunks expects.
+func ChunkCiphertext(ciphertext []byte) [MaxCiphertextChunks]*big.Int {
+	var chunks [MaxCiphertextChunks]*big.Int
+	for i := range chunks {
+		chunks[i] = new(big.Int)
+		start := i * ciphertextChunkSize
+		if start >= len(ciphertext) {
+			continue
+		}
+		end := start + ciphertextChunkSize
+		if end > len(ciphertext) {
+			end = len(ciphertext)
+		}
+		chunks[i].SetBytes(ciphertext[start:end])
+	}
+	return chunks
+}
+
+// CommitCiphertext computes, natively (outside of a circuit), the same
+// MiMC commitment assertCiphertextCommitment checks in-circuit, so callers
+// verifying a proof can supply it as the CiphertextCommitment public input
+// without re-running the prover.
+func CommitCiphertext(ciphertext []byte) *big.Int {
+	h := gnarkmimc.NewMiMC()
+	for _, chunk := range ChunkCiphertext(ciphertext) {
+		b := chunk.Bytes()
+		padded := make([]byte, 32)
+		copy(padded[32-len(b):], b)
+		h.Write(padded)
+	}
+	return new(big.Int).SetBytes(h.Sum(nil))
+}
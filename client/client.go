@@ -0,0 +1,165 @@
+// Package client provides a typed wrapper over the Fabric Gateway SDK for
+// votopreferencial/zk's chaincode API, so integrators marshal transaction
+// arguments and decode chaincode events in one place instead of each
+// caller hand-rolling it.
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+
+	fabricclient "github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// chaincodeName is the name this chaincode is expected to be deployed
+// under; Watch needs it to subscribe to the channel's chaincode events.
+const chaincodeName = "zk"
+
+// Client calls the votopreferencial/zk chaincode through a Fabric Gateway
+// contract handle.
+type Client struct {
+	network  *fabricclient.Network
+	contract *fabricclient.Contract
+}
+
+// New wraps an already-connected Fabric Gateway contract. network is the
+// contract's own network handle, kept separately because Watch
+// subscribes to events at the network (channel) level rather than through
+// the contract.
+func New(network *fabricclient.Network, contract *fabricclient.Contract) *Client {
+	return &Client{network: network, contract: contract}
+}
+
+// Candidate mirrors the chaincode's Candidate document.
+type Candidate struct {
+	Name      string `json:"name"`
+	VoteCount int    `json:"voteCount"`
+}
+
+// RoundResult mirrors the chaincode's RoundResult document.
+type RoundResult struct {
+	Round          int       `json:"round"`
+	Tallies        []float64 `json:"tallies"`
+	Elected        []int     `json:"elected,omitempty"`
+	Eliminated     int       `json:"eliminated"`
+	HasElimination bool      `json:"hasElimination"`
+}
+
+// ElectionResults mirrors the chaincode's ElectionResults document, as
+// returned by GetElectionResults.
+type ElectionResults struct {
+	Candidates  []Candidate   `json:"candidates"`
+	TallyMethod string        `json:"tallyMethod"`
+	Elected     []int         `json:"elected,omitempty"`
+	Rounds      []RoundResult `json:"rounds,omitempty"`
+}
+
+// ElectionStatus mirrors the chaincode's ElectionStatus document, as
+// returned by GetElectionStatus.
+type ElectionStatus struct {
+	State              string  `json:"state"`
+	Deadline           int64   `json:"deadline,omitempty"`
+	TotalVotes         int     `json:"totalVotes"`
+	ParticipationRatio float64 `json:"participationRatio"`
+	Quorum             string  `json:"quorum,omitempty"`
+	QuorumMet          bool    `json:"quorumMet"`
+	Threshold          int     `json:"threshold,omitempty"`
+}
+
+// CensusStep mirrors census.Step, duplicated here so callers can depend on
+// client without pulling in the chaincode module.
+type CensusStep struct {
+	Hash []byte `json:"hash"`
+	Left bool   `json:"left"`
+}
+
+// CensusProof mirrors census.Proof. Pass the zero value to CastVote when
+// the election has no census registered.
+type CensusProof struct {
+	Key   []byte       `json:"key"`
+	Value []byte       `json:"value"`
+	Steps []CensusStep `json:"steps"`
+}
+
+// InitElection submits InitElection with the given candidates and config.
+func (c *Client) InitElection(candidateNames []string, seats int, tallyMethod string, bordaWeights []int, anonymous bool, censusRoot []byte, censusSize int, verifyingKey []byte, trustees []string, deadline int64, quorum string, threshold int) error {
+	args, err := marshalArgs(candidateNames, seats, tallyMethod, bordaWeights, anonymous, censusRoot, censusSize, verifyingKey, trustees, deadline, quorum, threshold)
+	if err != nil {
+		return err
+	}
+	_, err = c.contract.SubmitTransaction("InitElection", args...)
+	return err
+}
+
+// OpenElection submits OpenElection, moving the election from Draft to
+// Open so CastVote starts accepting ballots.
+func (c *Client) OpenElection() error {
+	_, err := c.contract.SubmitTransaction("OpenElection")
+	return err
+}
+
+// CloseElection submits CloseElection, moving the election from Open to
+// Tallying so CountVotes (or TallyDecrypt, for anonymous elections) may
+// run.
+func (c *Client) CloseElection() error {
+	_, err := c.contract.SubmitTransaction("CloseElection")
+	return err
+}
+
+// CastVote submits a ranked ballot, with a census membership proof when
+// the election requires one (pass the zero value of CensusProof
+// otherwise).
+func (c *Client) CastVote(voter string, preferences []int, proof CensusProof) error {
+	args, err := marshalArgs(voter, preferences, proof)
+	if err != nil {
+		return err
+	}
+	_, err = c.contract.SubmitTransaction("CastVote", args...)
+	return err
+}
+
+// GetElectionResults evaluates GetElectionResults and decodes the result.
+func (c *Client) GetElectionResults() (*ElectionResults, error) {
+	data, err := c.contract.EvaluateTransaction("GetElectionResults")
+	if err != nil {
+		return nil, err
+	}
+	var results ElectionResults
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("decode election results: %w", err)
+	}
+	return &results, nil
+}
+
+// GetElectionStatus evaluates GetElectionStatus and decodes the result.
+func (c *Client) GetElectionStatus() (*ElectionStatus, error) {
+	data, err := c.contract.EvaluateTransaction("GetElectionStatus")
+	if err != nil {
+		return nil, err
+	}
+	var status ElectionStatus
+	if err := json.Unmarshal(data, &status); err != nil {
+		return nil, fmt.Errorf("decode election status: %w", err)
+	}
+	return &status, nil
+}
+
+// marshalArgs converts each value into the string form contractapi
+// expects on the wire: plain strings pass through untouched, everything
+// else (ints, slices, structs) is JSON-encoded the same way contractapi
+// decodes it on the chaincode side.
+func marshalArgs(values ...interface{}) ([]string, error) {
+	args := make([]string, len(values))
+	for i, v := range values {
+		if str, ok := v.(string); ok {
+			args[i] = str
+			continue
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("marshal argument %d: %w", i, err)
+		}
+		args[i] = string(b)
+	}
+	return args, nil
+}
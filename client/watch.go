@@ -0,0 +1,36 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Event is a decoded chaincode event emitted by CastVote or CountVotes
+// (see events.go in the chaincode module).
+type Event struct {
+	Name    string
+	Payload json.RawMessage
+}
+
+// Watch subscribes to the channel's chaincode events and invokes handler
+// for each one emitted by this contract's chaincode, until ctx is
+// canceled or the event stream closes.
+func (c *Client) Watch(ctx context.Context, handler func(Event)) error {
+	events, err := c.network.ChaincodeEvents(ctx, chaincodeName)
+	if err != nil {
+		return fmt.Errorf("subscribe to chaincode events: %w", err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case evt, ok := <-events:
+			if !ok {
+				return nil
+			}
+			handler(Event{Name: evt.EventName, Payload: evt.Payload})
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Chaincode event names emitted by CastVote and CountVotes, for clients
+// such as gateway's SSE endpoint to subscribe to (see client.Watch).
+const (
+	eventCastVote   = "CastVote"
+	eventCountVotes = "CountVotes"
+)
+
+type castVoteEvent struct {
+	Voter      string `json:"voter"`
+	TotalVotes int    `json:"totalVotes"`
+}
+
+func emitCastVote(ctx contractapi.TransactionContextInterface, voter string, totalVotes int) error {
+	payload, err := json.Marshal(castVoteEvent{Voter: voter, TotalVotes: totalVotes})
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().SetEvent(eventCastVote, payload)
+}
+
+type countVotesEvent struct {
+	Elected []int `json:"elected,omitempty"`
+}
+
+func emitCountVotes(ctx contractapi.TransactionContextInterface, elected []int) error {
+	payload, err := json.Marshal(countVotesEvent{Elected: elected})
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().SetEvent(eventCountVotes, payload)
+}
@@ -0,0 +1,40 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/votopreferencial/zk/client"
+)
+
+// handleEvents streams CastVote/CountVotes chaincode events (see events.go
+// in the chaincode module) to the client as Server-Sent Events, until the
+// request's context is canceled (e.g. the client disconnects).
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	err := s.zk.Watch(r.Context(), func(evt client.Event) {
+		data, err := json.Marshal(evt.Payload)
+		if err != nil {
+			log.Printf("gateway: encode event %s: %v", evt.Name, err)
+			return
+		}
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", evt.Name, data)
+		flusher.Flush()
+	})
+	if err != nil && r.Context().Err() == nil {
+		log.Printf("gateway: event stream ended: %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+//go:build integration
+
+// This file drives a full election through the REST surface against a
+// live Fabric test network. It is excluded from the default build (see
+// the integration build tag) because it needs a deployed chaincode and
+// network connection profile to run against:
+//
+//	go test -tags integration ./gateway/... \
+//		-net-endpoint peer0.org1.example.com:7051 \
+//		-net-channel mychannel -net-chaincode zk
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	fabricclient "github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+
+	zkclient "github.com/votopreferencial/zk/client"
+)
+
+var (
+	netEndpoint  = flag.String("net-endpoint", "localhost:7051", "peer gateway endpoint")
+	netChannel   = flag.String("net-channel", "mychannel", "Fabric channel name")
+	netChaincode = flag.String("net-chaincode", "zk", "deployed chaincode name")
+)
+
+// connectTestNetwork dials the peer gateway endpoint using the MSP
+// identity and TLS material laid out the way fabric-samples' test-network
+// script generates them (see $FABRIC_MSP_DIR / $FABRIC_TLS_CERT_PATH).
+func connectTestNetwork(t *testing.T) *fabricclient.Gateway {
+	t.Helper()
+
+	certPath := os.Getenv("FABRIC_TLS_CERT_PATH")
+	if certPath == "" {
+		t.Skip("FABRIC_TLS_CERT_PATH not set; no Fabric test network to connect to")
+	}
+
+	cert, err := os.ReadFile(certPath)
+	if err != nil {
+		t.Fatalf("read TLS cert: %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(cert)
+
+	conn, err := grpc.NewClient(*netEndpoint, grpc.WithTransportCredentials(credentials.NewClientTLSFromCert(pool, "")))
+	if err != nil {
+		t.Fatalf("dial peer gateway: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	id, err := identity.NewX509Identity("Org1MSP", cert)
+	if err != nil {
+		t.Fatalf("load identity: %v", err)
+	}
+
+	gw, err := fabricclient.Connect(id, fabricclient.WithClientConnection(conn))
+	if err != nil {
+		t.Fatalf("connect gateway: %v", err)
+	}
+	t.Cleanup(func() { gw.Close() })
+	return gw
+}
+
+func TestFullElectionOverREST(t *testing.T) {
+	gw := connectTestNetwork(t)
+	network := gw.GetNetwork(*netChannel)
+	contract := network.GetContract(*netChaincode)
+
+	zk := zkclient.New(network, contract)
+	srv := New(zk)
+	mux := http.NewServeMux()
+	srv.Routes(mux)
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var seenVote bool
+	events := make(chan struct{})
+	go func() {
+		resp, err := http.Get(ts.URL + "/elections/e1/events")
+		if err != nil {
+			return
+		}
+		defer resp.Body.Close()
+		buf := make([]byte, 4096)
+		n, _ := resp.Body.Read(buf)
+		if bytes.Contains(buf[:n], []byte("event: CastVote")) {
+			seenVote = true
+		}
+		close(events)
+	}()
+
+	initBody, _ := json.Marshal(initElectionRequest{
+		Candidates:  []string{"Alice", "Bob"},
+		Seats:       1,
+		TallyMethod: "stv",
+	})
+	resp, err := http.Post(ts.URL+"/elections", "application/json", bytes.NewReader(initBody))
+	if err != nil {
+		t.Fatalf("InitElection: %v", err)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("InitElection status = %d, want %d", resp.StatusCode, http.StatusCreated)
+	}
+
+	resp, err = http.Post(ts.URL+"/elections/e1/open", "application/json", nil)
+	if err != nil {
+		t.Fatalf("OpenElection: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("OpenElection status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	voteBody, _ := json.Marshal(castVoteRequest{Voter: "v1", Preferences: []int{0, 1}})
+	resp, err = http.Post(ts.URL+"/elections/e1/vote", "application/json", bytes.NewReader(voteBody))
+	if err != nil {
+		t.Fatalf("CastVote: %v", err)
+	}
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("CastVote status = %d, want %d", resp.StatusCode, http.StatusAccepted)
+	}
+
+	select {
+	case <-events:
+		if !seenVote {
+			t.Fatalf("event stream never reported a CastVote event")
+		}
+	case <-ctx.Done():
+		t.Fatalf("timed out waiting for CastVote event")
+	}
+
+	resp, err = http.Get(ts.URL + "/elections/e1/status")
+	if err != nil {
+		t.Fatalf("GetElectionStatus: %v", err)
+	}
+	defer resp.Body.Close()
+	var status zkclient.ElectionStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		t.Fatalf("decode status: %v", err)
+	}
+	if status.TotalVotes != 1 {
+		t.Fatalf("TotalVotes = %d, want 1", status.TotalVotes)
+	}
+}
@@ -0,0 +1,133 @@
+// Package gateway exposes the votopreferencial/zk chaincode over REST and
+// SSE, wrapping client.Client so UIs and CLIs can integrate without
+// embedding the Fabric Gateway SDK themselves.
+package gateway
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/votopreferencial/zk/client"
+)
+
+// Server adapts client.Client's typed chaincode calls to HTTP handlers.
+type Server struct {
+	zk *client.Client
+}
+
+// New returns a Server that drives the chaincode through an
+// already-connected client.
+func New(zk *client.Client) *Server {
+	return &Server{zk: zk}
+}
+
+// Routes registers the gateway's handlers on mux. The {id} path segment
+// is accepted for REST conventionality but not otherwise consulted: the
+// chaincode tracks a single election per deployment.
+func (s *Server) Routes(mux *http.ServeMux) {
+	mux.HandleFunc("POST /elections", s.handleInitElection)
+	mux.HandleFunc("POST /elections/{id}/open", s.handleOpenElection)
+	mux.HandleFunc("POST /elections/{id}/close", s.handleCloseElection)
+	mux.HandleFunc("POST /elections/{id}/vote", s.handleCastVote)
+	mux.HandleFunc("GET /elections/{id}/results", s.handleResults)
+	mux.HandleFunc("GET /elections/{id}/status", s.handleStatus)
+	mux.HandleFunc("GET /elections/{id}/events", s.handleEvents)
+}
+
+type initElectionRequest struct {
+	Candidates   []string `json:"candidates"`
+	Seats        int      `json:"seats"`
+	TallyMethod  string   `json:"tallyMethod"`
+	BordaWeights []int    `json:"bordaWeights"`
+	Anonymous    bool     `json:"anonymous"`
+	CensusRoot   []byte   `json:"censusRoot"`
+	CensusSize   int      `json:"censusSize"`
+	VerifyingKey []byte   `json:"verifyingKey"`
+	Trustees     []string `json:"trustees"`
+	Deadline     int64    `json:"deadline"`
+	Quorum       string   `json:"quorum"`
+	Threshold    int      `json:"threshold"`
+}
+
+func (s *Server) handleInitElection(w http.ResponseWriter, r *http.Request) {
+	var req initElectionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	err := s.zk.InitElection(req.Candidates, req.Seats, req.TallyMethod, req.BordaWeights,
+		req.Anonymous, req.CensusRoot, req.CensusSize, req.VerifyingKey, req.Trustees,
+		req.Deadline, req.Quorum, req.Threshold)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *Server) handleOpenElection(w http.ResponseWriter, r *http.Request) {
+	if err := s.zk.OpenElection(); err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) handleCloseElection(w http.ResponseWriter, r *http.Request) {
+	if err := s.zk.CloseElection(); err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+type castVoteRequest struct {
+	Voter       string             `json:"voter"`
+	Preferences []int              `json:"preferences"`
+	CensusProof client.CensusProof `json:"censusProof"`
+}
+
+func (s *Server) handleCastVote(w http.ResponseWriter, r *http.Request) {
+	var req castVoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.zk.CastVote(req.Voter, req.Preferences, req.CensusProof); err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *Server) handleResults(w http.ResponseWriter, r *http.Request) {
+	results, err := s.zk.GetElectionResults()
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := s.zk.GetElectionStatus()
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("gateway: encode response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	log.Printf("gateway: %v", err)
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
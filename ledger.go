@@ -0,0 +1,316 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	ballotObjectType = "ballot"
+	voterObjectType  = "ballotVoter"
+)
+
+// appendBallot writes voter's ballot as the next entry in the election's
+// append-only log (world-state key ballot/{electionID}/{seqNo}) and folds
+// it into the running Merkle root kept on e.BallotsRoot, instead of
+// rewriting a ballots array that would grow on every vote. It returns the
+// sequence number the ballot was recorded under.
+func appendBallot(ctx contractapi.TransactionContextInterface, e *Election, voter string, preferences []int) (int, error) {
+	voterKey, err := ctx.GetStub().CreateCompositeKey(voterObjectType, []string{e.ID, voter})
+	if err != nil {
+		return 0, err
+	}
+	existing, err := ctx.GetStub().GetState(voterKey)
+	if err != nil {
+		return 0, err
+	}
+	if existing != nil {
+		return 0, fmt.Errorf("voter has already voted")
+	}
+
+	seqNo := e.BallotSeq
+	ballotJSON, err := json.Marshal(Ballot{Voter: voter, Preferences: preferences, Voted: true})
+	if err != nil {
+		return 0, err
+	}
+
+	ballotKey, err := ctx.GetStub().CreateCompositeKey(ballotObjectType, []string{e.ID, ballotSeqComponent(seqNo)})
+	if err != nil {
+		return 0, err
+	}
+	if err := ctx.GetStub().PutState(ballotKey, ballotJSON); err != nil {
+		return 0, err
+	}
+	if err := ctx.GetStub().PutState(voterKey, []byte(strconv.Itoa(seqNo))); err != nil {
+		return 0, err
+	}
+
+	leaf := ballotLeaf(voter, preferences, seqNo)
+	e.BallotPeaks = appendLeaf(e.BallotPeaks, leaf[:])
+	e.BallotsRoot = bagPeaks(e.BallotPeaks)
+	e.BallotSeq++
+
+	return seqNo, nil
+}
+
+// loadBallots streams every ballot cast in electionID's append-only log via
+// GetStateByPartialCompositeKey, in seqNo order, so callers such as
+// CountVotes never have to unmarshal a monolithic ballots document.
+func loadBallots(ctx contractapi.TransactionContextInterface, electionID string) ([]Ballot, error) {
+	iter, err := ctx.GetStub().GetStateByPartialCompositeKey(ballotObjectType, []string{electionID})
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	var ballots []Ballot
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		var b Ballot
+		if err := json.Unmarshal(kv.Value, &b); err != nil {
+			return nil, err
+		}
+		ballots = append(ballots, b)
+	}
+	return ballots, nil
+}
+
+func ballotSeqComponent(seqNo int) string {
+	return fmt.Sprintf("%020d", seqNo)
+}
+
+// ballotLeaf is the append-only log's leaf hash for a single ballot.
+func ballotLeaf(voter string, preferences []int, seqNo int) [32]byte {
+	prefsJSON, _ := json.Marshal(preferences)
+	data := append([]byte(voter), prefsJSON...)
+	data = append(data, []byte(strconv.Itoa(seqNo))...)
+	return sha256.Sum256(data)
+}
+
+func hashPair(left, right []byte) []byte {
+	h := sha256.Sum256(append(append([]byte{}, left...), right...))
+	return h[:]
+}
+
+// appendLeaf folds a new leaf into a Merkle Mountain Range: peaks[h] holds
+// the root of the perfect subtree covering 2^h consecutive leaves, or nil
+// where no such subtree currently exists. Appending is exactly binary
+// counter increment: a new leaf enters at height 0 and merges upward
+// through every consecutive occupied slot, carrying a single new peak.
+func appendLeaf(peaks [][]byte, leaf []byte) [][]byte {
+	carry := leaf
+	for h := 0; h < len(peaks); h++ {
+		if peaks[h] == nil {
+			peaks[h] = carry
+			return peaks
+		}
+		carry = hashPair(peaks[h], carry)
+		peaks[h] = nil
+	}
+	return append(peaks, carry)
+}
+
+// bagPeaks combines an MMR's peaks, largest (earliest leaves) first, into
+// a single root.
+func bagPeaks(peaks [][]byte) []byte {
+	var root []byte
+	for h := len(peaks) - 1; h >= 0; h-- {
+		if peaks[h] == nil {
+			continue
+		}
+		if root == nil {
+			root = peaks[h]
+		} else {
+			root = hashPair(root, peaks[h])
+		}
+	}
+	return root
+}
+
+// recomputeRoot rebuilds the MMR root from scratch given every leaf in
+// order; used by VerifyLog to cross-check the incrementally maintained
+// BallotsRoot against a full replay of the log.
+func recomputeRoot(leaves [][]byte) []byte {
+	var peaks [][]byte
+	for _, leaf := range leaves {
+		peaks = appendLeaf(peaks, leaf)
+	}
+	return bagPeaks(peaks)
+}
+
+// decomposeBlocks splits n leaves into contiguous, descending powers-of-two
+// blocks matching the bits of n (and therefore matching how appendLeaf's
+// binary-counter merge grouped them).
+func decomposeBlocks(n int) []int {
+	var sizes []int
+	for bit := 1 << 30; bit > 0; bit >>= 1 {
+		if n&bit != 0 {
+			sizes = append(sizes, bit)
+		}
+	}
+	return sizes
+}
+
+func merkleRoot(leaves [][]byte) []byte {
+	if len(leaves) == 1 {
+		return leaves[0]
+	}
+	mid := len(leaves) / 2
+	return hashPair(merkleRoot(leaves[:mid]), merkleRoot(leaves[mid:]))
+}
+
+// MerkleProofStep is one sibling hash on the path from a leaf to the
+// election's BallotsRoot. Left indicates the sibling belongs on the left
+// of the running hash when the proof is replayed.
+type MerkleProofStep struct {
+	Hash []byte `json:"hash"`
+	Left bool   `json:"left"`
+}
+
+// merkleProofWithinBlock returns the root of a perfect-subtree block and
+// the sibling path from leaves[target] up to that root.
+func merkleProofWithinBlock(leaves [][]byte, target int) ([]byte, []MerkleProofStep) {
+	if len(leaves) == 1 {
+		return leaves[0], nil
+	}
+	mid := len(leaves) / 2
+	if target < mid {
+		root, steps := merkleProofWithinBlock(leaves[:mid], target)
+		sibling := merkleRoot(leaves[mid:])
+		return hashPair(root, sibling), append(steps, MerkleProofStep{Hash: sibling, Left: false})
+	}
+	root, steps := merkleProofWithinBlock(leaves[mid:], target-mid)
+	sibling := merkleRoot(leaves[:mid])
+	return hashPair(sibling, root), append(steps, MerkleProofStep{Hash: sibling, Left: true})
+}
+
+// buildInclusionProof returns the sibling path from leaves[target] to the
+// MMR root over leaves: first the path to the target's own block root
+// (from merkleProofWithinBlock), then the bagging steps that fold that
+// block root together with its peer blocks into the final root.
+func buildInclusionProof(leaves [][]byte, target int) ([]MerkleProofStep, []byte) {
+	blockSizes := decomposeBlocks(len(leaves))
+
+	offset := 0
+	foundBlock := -1
+	var steps []MerkleProofStep
+	blockRoots := make([][]byte, len(blockSizes))
+	for bi, size := range blockSizes {
+		block := leaves[offset : offset+size]
+		if target >= offset && target < offset+size {
+			var root []byte
+			root, steps = merkleProofWithinBlock(block, target-offset)
+			blockRoots[bi] = root
+			foundBlock = bi
+		} else {
+			blockRoots[bi] = merkleRoot(block)
+		}
+		offset += size
+	}
+
+	var acc []byte
+	for bi, br := range blockRoots {
+		switch {
+		case acc == nil:
+			acc = br
+		case bi == foundBlock:
+			steps = append(steps, MerkleProofStep{Hash: acc, Left: true})
+			acc = hashPair(acc, br)
+		case bi > foundBlock:
+			steps = append(steps, MerkleProofStep{Hash: br, Left: false})
+			acc = hashPair(acc, br)
+		default:
+			acc = hashPair(acc, br)
+		}
+	}
+
+	return steps, acc
+}
+
+// BallotInclusionProof lets an external auditor recompute BallotsRoot from
+// a single leaf, proving voter's ballot is really in the log without
+// trusting the peer that served it.
+type BallotInclusionProof struct {
+	Voter string            `json:"voter"`
+	SeqNo int               `json:"seqNo"`
+	Leaf  []byte            `json:"leaf"`
+	Steps []MerkleProofStep `json:"steps"`
+	Root  []byte            `json:"root"`
+}
+
+func (s *SmartContract) GetBallotInclusionProof(ctx contractapi.TransactionContextInterface, voter string) (*BallotInclusionProof, error) {
+	election, err := readElection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	voterKey, err := ctx.GetStub().CreateCompositeKey(voterObjectType, []string{election.ID, voter})
+	if err != nil {
+		return nil, err
+	}
+	seqNoBytes, err := ctx.GetStub().GetState(voterKey)
+	if err != nil {
+		return nil, err
+	}
+	if seqNoBytes == nil {
+		return nil, fmt.Errorf("%q has not cast a ballot", voter)
+	}
+	seqNo, err := strconv.Atoi(string(seqNoBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	ballots, err := loadBallots(ctx, election.ID)
+	if err != nil {
+		return nil, err
+	}
+	if seqNo >= len(ballots) {
+		return nil, fmt.Errorf("ballot log is inconsistent: seqNo %d out of range", seqNo)
+	}
+
+	leaves := ballotLeaves(ballots)
+	steps, root := buildInclusionProof(leaves, seqNo)
+
+	return &BallotInclusionProof{
+		Voter: voter,
+		SeqNo: seqNo,
+		Leaf:  leaves[seqNo],
+		Steps: steps,
+		Root:  root,
+	}, nil
+}
+
+// VerifyLog walks the election's entire ballot range and re-derives the
+// Merkle root from scratch, reporting whether it matches the incrementally
+// maintained Election.BallotsRoot.
+func (s *SmartContract) VerifyLog(ctx contractapi.TransactionContextInterface) (bool, error) {
+	election, err := readElection(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	ballots, err := loadBallots(ctx, election.ID)
+	if err != nil {
+		return false, err
+	}
+
+	root := recomputeRoot(ballotLeaves(ballots))
+	return bytes.Equal(root, election.BallotsRoot), nil
+}
+
+func ballotLeaves(ballots []Ballot) [][]byte {
+	leaves := make([][]byte, len(ballots))
+	for i, b := range ballots {
+		leaf := ballotLeaf(b.Voter, b.Preferences, i)
+		leaves[i] = leaf[:]
+	}
+	return leaves
+}
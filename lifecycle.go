@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ElectionState tracks where an election is in its lifecycle. Transitions
+// are strictly forward: Draft -> Open -> Tallying -> Closed, with
+// CancelElection able to jump from Draft or Open straight to Closed.
+type ElectionState string
+
+const (
+	StateDraft    ElectionState = "Draft"
+	StateOpen     ElectionState = "Open"
+	StateTallying ElectionState = "Tallying"
+	StateClosed   ElectionState = "Closed"
+)
+
+// OpenElection moves a Draft election into Open, allowing CastVote to
+// start accepting ballots.
+func (s *SmartContract) OpenElection(ctx contractapi.TransactionContextInterface) error {
+	election, err := readElection(ctx)
+	if err != nil {
+		return err
+	}
+	if election.State != StateDraft {
+		return fmt.Errorf("election must be in the Draft state to open, got %s", election.State)
+	}
+
+	election.State = StateOpen
+	return putElection(ctx, election)
+}
+
+// CloseElection moves an Open election into Tallying, after which no
+// further ballots are accepted and CountVotes (or TallyDecrypt, for
+// anonymous elections) may run.
+func (s *SmartContract) CloseElection(ctx contractapi.TransactionContextInterface) error {
+	election, err := readElection(ctx)
+	if err != nil {
+		return err
+	}
+	if election.State != StateOpen {
+		return fmt.Errorf("election must be in the Open state to close, got %s", election.State)
+	}
+
+	election.State = StateTallying
+	return putElection(ctx, election)
+}
+
+// CancelElection closes a Draft or Open election without ever tallying it.
+func (s *SmartContract) CancelElection(ctx contractapi.TransactionContextInterface) error {
+	election, err := readElection(ctx)
+	if err != nil {
+		return err
+	}
+	if election.State != StateDraft && election.State != StateOpen {
+		return fmt.Errorf("election can only be cancelled from the Draft or Open state, got %s", election.State)
+	}
+
+	election.State = StateClosed
+	return putElection(ctx, election)
+}
+
+// ElectionStatus is the lightweight status summary returned by
+// GetElectionStatus, letting clients drive UI without replaying the full
+// election payload (ballots, rounds, ...).
+type ElectionStatus struct {
+	State              ElectionState `json:"state"`
+	Deadline           int64         `json:"deadline,omitempty"`
+	TotalVotes         int           `json:"totalVotes"`
+	ParticipationRatio float64       `json:"participationRatio"`
+	Quorum             string        `json:"quorum,omitempty"`
+	QuorumMet          bool          `json:"quorumMet"`
+	Threshold          int           `json:"threshold,omitempty"`
+}
+
+func (s *SmartContract) GetElectionStatus(ctx contractapi.TransactionContextInterface) (*ElectionStatus, error) {
+	election, err := readElection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ratio, quorumMet := evaluateQuorum(&election)
+	return &ElectionStatus{
+		State:              election.State,
+		Deadline:           election.Deadline,
+		TotalVotes:         election.TotalVotes,
+		ParticipationRatio: ratio,
+		Quorum:             election.Quorum,
+		QuorumMet:          quorumMet,
+		Threshold:          election.Threshold,
+	}, nil
+}
+
+// evaluateQuorum returns the participation ratio of ballots cast against
+// e.CensusSize (0 when no census was registered, in which case ratio is
+// meaningless and only an absolute Quorum can be evaluated), and whether
+// e.Quorum is satisfied. An empty Quorum means none was configured, so it
+// is always considered met regardless of census or turnout; "SIMPLE" and
+// "TWO_THIRDS" need a registered census to have a denominator to compare
+// against and are trivially met without one. An absolute-count Quorum is
+// always evaluated against e.TotalVotes directly, census or not.
+func evaluateQuorum(e *Election) (ratio float64, met bool) {
+	if e.CensusSize > 0 {
+		ratio = float64(e.TotalVotes) / float64(e.CensusSize)
+	}
+
+	switch e.Quorum {
+	case "":
+		return ratio, true
+	case "SIMPLE":
+		if e.CensusSize == 0 {
+			return ratio, true
+		}
+		return ratio, ratio > 0.5
+	case "TWO_THIRDS":
+		if e.CensusSize == 0 {
+			return ratio, true
+		}
+		return ratio, ratio >= 2.0/3.0
+	default:
+		n, err := strconv.Atoi(e.Quorum)
+		if err != nil {
+			return ratio, true
+		}
+		return ratio, e.TotalVotes >= n
+	}
+}
+
+// applyThreshold drops any elected candidate whose final tally fell short
+// of e.Threshold, leaving that seat unfilled.
+func applyThreshold(e *Election) {
+	if e.Threshold <= 0 {
+		return
+	}
+
+	var kept []int
+	for _, c := range e.Elected {
+		if e.Candidates[c].VoteCount >= e.Threshold {
+			kept = append(kept, c)
+		}
+	}
+	e.Elected = kept
+}
+
+// deadlineHasPassed reports whether the transaction's (deterministic)
+// timestamp is later than deadline. Chaincode has no wall clock of its
+// own, so it must use the ordering service's timestamp for the current
+// transaction rather than time.Now().
+func deadlineHasPassed(ctx contractapi.TransactionContextInterface, deadline int64) (bool, error) {
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return false, err
+	}
+	txTime, err := ptypes.Timestamp(txTimestamp)
+	if err != nil {
+		return false, err
+	}
+	return txTime.Unix() > deadline, nil
+}
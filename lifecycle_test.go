@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestApplyThresholdSTVQuotaWinners(t *testing.T) {
+	// Mirrors TestRunSTVSurplusTransfer: A and B are elected with 4 and 3
+	// votes respectively. A Threshold of 4 should drop B (elected with only
+	// 3) while keeping A, exercising the surplus-transfer scenario the
+	// reviewer flagged as silently dropping legitimate winners when
+	// VoteCount was clobbered by the last round's tallies.
+	e, ballots := newElection(2, 3,
+		ballot("v1", 0, 1),
+		ballot("v2", 0, 1),
+		ballot("v3", 0, 1),
+		ballot("v4", 0, 1),
+		ballot("v5", 1),
+	)
+	e.Threshold = 4
+
+	rounds, elected, err := runSTV(e, ballots)
+	if err != nil {
+		t.Fatalf("runSTV returned error: %v", err)
+	}
+	e.Rounds, e.Elected = rounds, elected
+
+	applyThreshold(e)
+	if len(e.Elected) != 1 || e.Elected[0] != 0 {
+		t.Fatalf("Elected = %v, want [0] (B's 3 votes fall short of Threshold 4)", e.Elected)
+	}
+}
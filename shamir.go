@@ -0,0 +1,103 @@
+package main
+
+import "fmt"
+
+// gf256Exp/gf256Log are lookup tables for GF(2^8) multiplication and
+// division, generated from 3 (a generator of the field under AES's
+// reducing polynomial x^8+x^4+x^3+x+1); this is the same field Shamir
+// secret sharing implementations conventionally use for byte-wise shares.
+var gf256Exp [510]byte
+var gf256Log [256]byte
+
+func init() {
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		gf256Exp[i] = x
+		gf256Log[x] = byte(i)
+		x = gf256MulNoLog(x, 3)
+	}
+	for i := 255; i < 510; i++ {
+		gf256Exp[i] = gf256Exp[i-255]
+	}
+}
+
+// gf256MulNoLog multiplies in GF(2^8) by repeated doubling-and-reduce;
+// used only to build the log/exp tables above, since it doesn't itself
+// need them.
+func gf256MulNoLog(a, b byte) byte {
+	var p byte
+	for i := 0; i < 8; i++ {
+		if b&1 != 0 {
+			p ^= a
+		}
+		carry := a & 0x80
+		a <<= 1
+		if carry != 0 {
+			a ^= 0x1b
+		}
+		b >>= 1
+	}
+	return p
+}
+
+func gf256Mul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gf256Exp[int(gf256Log[a])+int(gf256Log[b])]
+}
+
+func gf256Div(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return gf256Exp[(int(gf256Log[a])-int(gf256Log[b])+255)%255]
+}
+
+// shamirCombine reconstructs a secret byte string from shares, a map of
+// x-coordinate to that trustee's share of the secret (the same length as
+// the secret). It evaluates the Lagrange interpolation of the implied
+// polynomial at x=0, independently per byte, over GF(2^8) — the classic
+// Shamir secret sharing reconstruction. Supplying fewer than the original
+// threshold's worth of (correct) shares, or shares that were never issued
+// together, reconstructs a different byte string rather than failing
+// outright; callers must check the result decrypts to something sensible.
+func shamirCombine(shares map[int][]byte) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, fmt.Errorf("no shares to combine")
+	}
+
+	xs := make([]int, 0, len(shares))
+	secretLen := -1
+	for x, share := range shares {
+		if x <= 0 || x > 255 {
+			return nil, fmt.Errorf("share x-coordinate %d out of range", x)
+		}
+		if secretLen == -1 {
+			secretLen = len(share)
+		} else if len(share) != secretLen {
+			return nil, fmt.Errorf("shares have inconsistent lengths")
+		}
+		xs = append(xs, x)
+	}
+
+	secret := make([]byte, secretLen)
+	for byteIdx := 0; byteIdx < secretLen; byteIdx++ {
+		var acc byte
+		for _, xi := range xs {
+			num := byte(1)
+			den := byte(1)
+			for _, xj := range xs {
+				if xi == xj {
+					continue
+				}
+				num = gf256Mul(num, byte(xj))
+				den = gf256Mul(den, byte(xi)^byte(xj))
+			}
+			term := gf256Mul(shares[xi][byteIdx], gf256Div(num, den))
+			acc ^= term
+		}
+		secret[byteIdx] = acc
+	}
+	return secret, nil
+}
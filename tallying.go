@@ -1,84 +1,216 @@
-package main
-
-import (
-	"fmt"
-	"sort"
-)
-
-// Estrutura para armazenar informações sobre uma candidata
-type Candidata struct {
-	Nome  string
-	Votos int
-}
-
-// Função para calcular os totais de votos ponderados
-func calcularTotais(votos [][]string, candidatasValidas []string, pesos []int) []Candidata {
-	resultados := make(map[string]int)
-
-	// Inicializando o mapa de resultados com as candidatas válidas
-	for _, candidata := range candidatasValidas {
-		resultados[candidata] = 0
-	}
-
-	// Aplicando os pesos para cada voto
-	for _, voto := range votos {
-		for i, candidata := range voto {
-			if i < len(pesos) && contains(candidatasValidas, candidata) {
-				resultados[candidata] += pesos[i]
-			}
-		}
-	}
-
-	// Convertendo o mapa de resultados para uma lista de Candidatas
-	var listaResultados []Candidata
-	for nome, total := range resultados {
-		listaResultados = append(listaResultados, Candidata{Nome: nome, Votos: total})
-	}
-
-	// Ordenando as candidatas por número de votos
-	sort.Slice(listaResultados, func(i, j int) bool {
-		return listaResultados[i].Votos > listaResultados[j].Votos
-	})
-
-	return listaResultados
-}
-
-// Função para verificar se a candidata está na lista de candidatas válidas
-func contains(candidatasValidas []string, candidata string) bool {
-	for _, c := range candidatasValidas {
-		if c == candidata {
-			return true
-		}
-	}
-	return false
-}
-
-// Função principal que simula a apuração
-func main() {
-	// Lista de candidatas válidas
-	candidatasValidas := []string{
-		"Tainá de Paula (PT)", "Rosa Fernandes (PSD)", "Joyce Trindade (PSD)", "Helena Vieira (PSD)",
-		"Vera Lins (PP)", "Monica Benicio (PSOL)", "Tânia Bastos (REPUBLICANOS)", "Talita Galhardo (PSDB)",
-		"Thais Ferreira (PSOL)", "Tatiana Roque (PSB)", "Maíra do MST (PT)", "Gigi Castilho (REPUBLICANOS)",
-	}
-
-	// Simulando uma tabela de votos onde cada linha representa um voto com as preferências
-	votos := [][]string{
-		{"Tainá de Paula (PT)", "Monica Benicio (PSOL)", "Thais Ferreira (PSOL)"},
-		{"Tatiana Roque (PSB)", "Joyce Trindade (PSD)", "Maíra do MST (PT)"},
-		{"Rosa Fernandes (PSD)", "Vera Lins (PP)", "Tânia Bastos (REPUBLICANOS)"},
-		{"Monica Benicio (PSOL)", "Thais Ferreira (PSOL)", "Tainá de Paula (PT)"},
-	}
-
-	// Definindo os pesos para o sistema de apuração
-	pesos := []int{3, 2, 1}
-
-	// Chamando a função para calcular os totais
-	resultados := calcularTotais(votos, candidatasValidas, pesos)
-
-	// Exibindo os resultados
-	fmt.Println("Resultados da apuração STV:")
-	for _, candidata := range resultados {
-		fmt.Printf("%s: %d votos ponderados\n", candidata.Nome, candidata.Votos)
-	}
-}
\ No newline at end of file
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// runSTV counts an election using single transferable vote with the Droop
+// quota and Gregory-method surplus transfers. Ballots carry a fractional
+// weight starting at 1.0; when a candidate is elected with a surplus, the
+// weight of every ballot currently assigned to them is multiplied by
+// transferValue = (total-quota)/total before moving on to each ballot's
+// next continuing preference. When no candidate meets quota, the lowest
+// continuing candidate is eliminated and their ballots move on at full
+// current weight. Ties are broken deterministically: among candidates tied
+// for lowest total, the one with the highest candidate index is eliminated.
+// Each candidate's Candidates[i].VoteCount is set from the round in which
+// they were elected or eliminated, not from whatever the final round's
+// tallies happen to hold (later rounds carry no ballots for a candidate who
+// already left continuing).
+func runSTV(e *Election, ballots []Ballot) ([]RoundResult, []int, error) {
+	n := len(e.Candidates)
+	if n == 0 {
+		return nil, nil, fmt.Errorf("election has no candidates")
+	}
+	if e.Seats <= 0 {
+		return nil, nil, fmt.Errorf("election has no seats to fill")
+	}
+
+	quota := len(ballots)/(e.Seats+1) + 1
+
+	continuing := make([]bool, n)
+	for i := range continuing {
+		continuing[i] = true
+	}
+
+	weights := make([]float64, len(ballots))
+	assigned := make([]int, len(ballots))
+	for i, b := range ballots {
+		weights[i] = 1.0
+		assigned[i] = nextContinuing(b.Preferences, continuing, 0)
+	}
+
+	var rounds []RoundResult
+	var elected []int
+	seatsRemaining := e.Seats
+	finalTally := make([]float64, n)
+
+	for seatsRemaining > 0 {
+		totals := make([]float64, n)
+		for i, c := range assigned {
+			if c >= 0 {
+				totals[c] += weights[i]
+			}
+		}
+
+		var continuingIdx []int
+		for i := 0; i < n; i++ {
+			if continuing[i] {
+				continuingIdx = append(continuingIdx, i)
+			}
+		}
+
+		round := RoundResult{Round: len(rounds) + 1, Tallies: totals}
+
+		if len(continuingIdx) <= seatsRemaining {
+			sortByTotalDesc(continuingIdx, totals)
+			for _, c := range continuingIdx {
+				continuing[c] = false
+				elected = append(elected, c)
+				round.Elected = append(round.Elected, c)
+				finalTally[c] = totals[c]
+				seatsRemaining--
+			}
+			rounds = append(rounds, round)
+			break
+		}
+
+		var metQuota []int
+		for _, c := range continuingIdx {
+			if totals[c] >= float64(quota) {
+				metQuota = append(metQuota, c)
+			}
+		}
+
+		if len(metQuota) > 0 {
+			sortByTotalDesc(metQuota, totals)
+			for _, c := range metQuota {
+				if seatsRemaining == 0 {
+					break
+				}
+				total := totals[c]
+				transferValue := (total - float64(quota)) / total
+
+				continuing[c] = false
+				elected = append(elected, c)
+				round.Elected = append(round.Elected, c)
+				finalTally[c] = total
+				seatsRemaining--
+
+				for i, assignedTo := range assigned {
+					if assignedTo != c {
+						continue
+					}
+					weights[i] *= transferValue
+					assigned[i] = nextContinuing(ballots[i].Preferences, continuing, indexOf(ballots[i].Preferences, c)+1)
+				}
+			}
+		} else {
+			lowest := continuingIdx[0]
+			for _, c := range continuingIdx[1:] {
+				if totals[c] < totals[lowest] || (totals[c] == totals[lowest] && c > lowest) {
+					lowest = c
+				}
+			}
+			round.HasElimination = true
+			round.Eliminated = lowest
+			continuing[lowest] = false
+			finalTally[lowest] = totals[lowest]
+
+			for i, assignedTo := range assigned {
+				if assignedTo != lowest {
+					continue
+				}
+				assigned[i] = nextContinuing(ballots[i].Preferences, continuing, indexOf(ballots[i].Preferences, lowest)+1)
+			}
+		}
+
+		rounds = append(rounds, round)
+	}
+
+	for i, total := range finalTally {
+		e.Candidates[i].VoteCount = int(total)
+	}
+
+	sort.Ints(elected)
+	return rounds, elected, nil
+}
+
+// nextContinuing returns the first candidate in prefs, starting at from,
+// that is still continuing, or -1 if the ballot is exhausted.
+func nextContinuing(prefs []int, continuing []bool, from int) int {
+	for i := from; i < len(prefs); i++ {
+		c := prefs[i]
+		if c >= 0 && c < len(continuing) && continuing[c] {
+			return c
+		}
+	}
+	return -1
+}
+
+func indexOf(xs []int, v int) int {
+	for i, x := range xs {
+		if x == v {
+			return i
+		}
+	}
+	return -1
+}
+
+// sortByTotalDesc sorts candidate indices by total descending, breaking
+// ties by candidate index ascending, so outcomes are deterministic.
+func sortByTotalDesc(idx []int, totals []float64) {
+	sort.Slice(idx, func(a, b int) bool {
+		if totals[idx[a]] != totals[idx[b]] {
+			return totals[idx[a]] > totals[idx[b]]
+		}
+		return idx[a] < idx[b]
+	})
+}
+
+// runBorda counts an election by awarding each ballot's rank-th preference
+// bordaWeights[rank] points, then seating the top Seats candidates by total
+// points (ties broken by candidate index ascending).
+func runBorda(e *Election, ballots []Ballot) ([]int, error) {
+	if len(e.BordaWeights) == 0 {
+		return nil, fmt.Errorf("borda tally requires bordaWeights")
+	}
+
+	n := len(e.Candidates)
+	totals := make([]int, n)
+	for _, b := range ballots {
+		for rank, c := range b.Preferences {
+			if rank >= len(e.BordaWeights) {
+				break
+			}
+			if c < 0 || c >= n {
+				continue
+			}
+			totals[c] += e.BordaWeights[rank]
+		}
+	}
+
+	for i := range e.Candidates {
+		e.Candidates[i].VoteCount = totals[i]
+	}
+
+	ranked := make([]int, n)
+	for i := range ranked {
+		ranked[i] = i
+	}
+	sort.Slice(ranked, func(a, b int) bool {
+		if totals[ranked[a]] != totals[ranked[b]] {
+			return totals[ranked[a]] > totals[ranked[b]]
+		}
+		return ranked[a] < ranked[b]
+	})
+
+	seats := e.Seats
+	if seats > n {
+		seats = n
+	}
+	elected := append([]int{}, ranked[:seats]...)
+	sort.Ints(elected)
+	return elected, nil
+}
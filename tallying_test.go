@@ -0,0 +1,175 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func ballot(voter string, prefs ...int) Ballot {
+	return Ballot{Voter: voter, Preferences: prefs, Voted: true}
+}
+
+func newElection(seats int, numCandidates int, ballots ...Ballot) (*Election, []Ballot) {
+	candidates := make([]Candidate, numCandidates)
+	e := &Election{
+		Candidates: candidates,
+		Seats:      seats,
+		TotalVotes: len(ballots),
+	}
+	return e, ballots
+}
+
+func TestRunSTVSurplusTransfer(t *testing.T) {
+	// A, B, C = 0, 1, 2. Seats = 2, quota = floor(5/3)+1 = 2.
+	// A's surplus of 2 (total 4, quota 2) transfers at half weight to B,
+	// who then also reaches quota.
+	e, ballots := newElection(2, 3,
+		ballot("v1", 0, 1),
+		ballot("v2", 0, 1),
+		ballot("v3", 0, 1),
+		ballot("v4", 0, 1),
+		ballot("v5", 1),
+	)
+
+	rounds, elected, err := runSTV(e, ballots)
+	if err != nil {
+		t.Fatalf("runSTV returned error: %v", err)
+	}
+	if !reflect.DeepEqual(elected, []int{0, 1}) {
+		t.Fatalf("elected = %v, want [0 1]", elected)
+	}
+	if len(rounds) != 2 {
+		t.Fatalf("len(rounds) = %d, want 2", len(rounds))
+	}
+	if !reflect.DeepEqual(rounds[0].Elected, []int{0}) {
+		t.Fatalf("round 1 elected = %v, want [0]", rounds[0].Elected)
+	}
+	if rounds[0].Tallies[0] != 4 {
+		t.Fatalf("round 1 tally for A = %v, want 4", rounds[0].Tallies[0])
+	}
+	if !reflect.DeepEqual(rounds[1].Elected, []int{1}) {
+		t.Fatalf("round 2 elected = %v, want [1]", rounds[1].Elected)
+	}
+	if rounds[1].Tallies[1] != 3 {
+		t.Fatalf("round 2 tally for B = %v, want 3 (1 + 4*0.5 transfer)", rounds[1].Tallies[1])
+	}
+}
+
+func TestRunSTVTieBreakByIndex(t *testing.T) {
+	// 4 candidates, each with exactly one first-preference vote and no
+	// fallback preferences. Every elimination round is a four-, then
+	// three-, then two-way tie; the rule eliminates the highest index
+	// each time, leaving candidate 0 as the sole winner.
+	e, ballots := newElection(1, 4,
+		ballot("v1", 0),
+		ballot("v2", 1),
+		ballot("v3", 2),
+		ballot("v4", 3),
+	)
+
+	_, elected, err := runSTV(e, ballots)
+	if err != nil {
+		t.Fatalf("runSTV returned error: %v", err)
+	}
+	if !reflect.DeepEqual(elected, []int{0}) {
+		t.Fatalf("elected = %v, want [0]", elected)
+	}
+}
+
+func TestRunSTVExhaustedBallot(t *testing.T) {
+	// Quota = floor(4/2)+1 = 3, which nobody reaches in round one. The
+	// ballot for v3 only ranks candidate 2, who ties candidate 1 for the
+	// lowest total and, having the higher index, is eliminated in round
+	// one; the ballot must become exhausted rather than crash or get
+	// reassigned to a phantom candidate.
+	e, ballots := newElection(1, 3,
+		ballot("v1", 0),
+		ballot("v2", 0),
+		ballot("v3", 2),
+		ballot("v4", 1),
+	)
+
+	rounds, elected, err := runSTV(e, ballots)
+	if err != nil {
+		t.Fatalf("runSTV returned error: %v", err)
+	}
+	if !reflect.DeepEqual(elected, []int{0}) {
+		t.Fatalf("elected = %v, want [0]", elected)
+	}
+	for _, r := range rounds {
+		for _, total := range r.Tallies {
+			if total < 0 {
+				t.Fatalf("negative tally %v in round %d", r.Tallies, r.Round)
+			}
+		}
+	}
+}
+
+func TestRunSTVEqualCountShortcut(t *testing.T) {
+	// Seats = 2, candidates A, B, C with one vote each (quota = 2, nobody
+	// reaches it). Once C is eliminated, the two remaining continuing
+	// candidates exactly match the two remaining seats and are elected
+	// without needing to meet quota.
+	e, ballots := newElection(2, 3,
+		ballot("v1", 0),
+		ballot("v2", 1),
+		ballot("v3", 2),
+	)
+
+	rounds, elected, err := runSTV(e, ballots)
+	if err != nil {
+		t.Fatalf("runSTV returned error: %v", err)
+	}
+	if !reflect.DeepEqual(elected, []int{0, 1}) {
+		t.Fatalf("elected = %v, want [0 1]", elected)
+	}
+	last := rounds[len(rounds)-1]
+	if !reflect.DeepEqual(last.Elected, []int{0, 1}) {
+		t.Fatalf("final round elected = %v, want [0 1]", last.Elected)
+	}
+}
+
+func TestRunSTVQuotaWinnerVoteCount(t *testing.T) {
+	// Same ballots as TestRunSTVSurplusTransfer: A is elected by quota in
+	// round 1 with 4 votes, then carries none of the later rounds' tallies
+	// (their ballots have moved on to B). A's VoteCount must still record
+	// the 4 votes that elected them, not 0.
+	e, ballots := newElection(2, 3,
+		ballot("v1", 0, 1),
+		ballot("v2", 0, 1),
+		ballot("v3", 0, 1),
+		ballot("v4", 0, 1),
+		ballot("v5", 1),
+	)
+
+	if _, _, err := runSTV(e, ballots); err != nil {
+		t.Fatalf("runSTV returned error: %v", err)
+	}
+	if e.Candidates[0].VoteCount != 4 {
+		t.Fatalf("A's VoteCount = %d, want 4 (the round it met quota in)", e.Candidates[0].VoteCount)
+	}
+	if e.Candidates[1].VoteCount != 3 {
+		t.Fatalf("B's VoteCount = %d, want 3 (the round it met quota in)", e.Candidates[1].VoteCount)
+	}
+}
+
+func TestRunBorda(t *testing.T) {
+	e, ballots := newElection(1, 3,
+		ballot("v1", 0, 1, 2),
+		ballot("v2", 1, 0, 2),
+	)
+	e.BordaWeights = []int{3, 2, 1}
+
+	elected, err := runBorda(e, ballots)
+	if err != nil {
+		t.Fatalf("runBorda returned error: %v", err)
+	}
+	// A: 3 (v1 1st) + 2 (v2 2nd) = 5; B: 2 (v1 2nd) + 3 (v2 1st) = 5; tie
+	// broken by candidate index, so A (0) wins the single seat.
+	if !reflect.DeepEqual(elected, []int{0}) {
+		t.Fatalf("elected = %v, want [0]", elected)
+	}
+	if e.Candidates[0].VoteCount != 5 || e.Candidates[1].VoteCount != 5 {
+		t.Fatalf("vote counts = %+v, want [5 5 2]", e.Candidates)
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+	"github.com/votopreferencial/zk/circuits"
+)
+
+// CastAnonymousVote accepts a ballot for an anonymous election. proof must
+// satisfy circuits.BallotCircuit against the election's CensusRoot: that
+// the caller knows a secret admitted to the census, that nullifier is that
+// secret's Poseidon hash bound to this election (so it can be rejected on
+// reuse without identifying the voter), that the plaintext preferences in
+// the prover's witness are a valid permutation of candidate indices, and
+// that ciphertextPreferences is the exact blob the proof was made for. The
+// proof does not show that ciphertextPreferences encrypts those plaintext
+// preferences; TallyDecrypt only checks that the decrypted bytes parse as
+// a JSON list of integers.
+func (s *SmartContract) CastAnonymousVote(ctx contractapi.TransactionContextInterface, nullifier string, ciphertextPreferences []byte, proof []byte) error {
+	election, err := readElection(ctx)
+	if err != nil {
+		return err
+	}
+	if !election.Anonymous {
+		return fmt.Errorf("this election does not accept anonymous votes")
+	}
+	if election.State != StateOpen {
+		return fmt.Errorf("election is not open for voting (state: %s)", election.State)
+	}
+	if election.Deadline > 0 {
+		past, err := deadlineHasPassed(ctx, election.Deadline)
+		if err != nil {
+			return err
+		}
+		if past {
+			return fmt.Errorf("voting deadline has passed")
+		}
+	}
+	if election.Nullifiers[nullifier] {
+		return fmt.Errorf("ballot already cast for this nullifier")
+	}
+
+	if err := verifyBallotProof(&election, nullifier, ciphertextPreferences, proof); err != nil {
+		return err
+	}
+
+	if election.Nullifiers == nil {
+		election.Nullifiers = map[string]bool{}
+	}
+	election.Nullifiers[nullifier] = true
+	election.EncryptedBallots = append(election.EncryptedBallots, EncryptedBallot{
+		Nullifier:  nullifier,
+		Ciphertext: ciphertextPreferences,
+	})
+	election.TotalVotes++
+
+	return putElection(ctx, election)
+}
+
+// verifyBallotProof checks proof against e's committed VerifyingKey using
+// the public inputs the chaincode itself can derive: CensusRoot, the
+// election ID (the nullifier's domain separator, reduced to a field
+// element the same way the nullifier itself is), the claimed nullifier,
+// the candidate count, and a commitment to ciphertext so the proof cannot
+// be replayed against a different encrypted ballot.
+func verifyBallotProof(e *Election, nullifier string, ciphertext []byte, proofBytes []byte) error {
+	proof := groth16.NewProof(ecc.BN254)
+	if _, err := proof.ReadFrom(bytes.NewReader(proofBytes)); err != nil {
+		return fmt.Errorf("decode proof: %w", err)
+	}
+
+	vk := groth16.NewVerifyingKey(ecc.BN254)
+	if _, err := vk.ReadFrom(bytes.NewReader(e.VerifyingKey)); err != nil {
+		return fmt.Errorf("decode verifying key: %w", err)
+	}
+
+	assignment := circuits.BallotCircuit{
+		CensusRoot:           new(big.Int).SetBytes(e.CensusRoot),
+		ElectionID:           stringToField(e.ID),
+		Nullifier:            stringToField(nullifier),
+		NumCandidates:        len(e.Candidates),
+		CiphertextCommitment: circuits.CommitCiphertext(ciphertext),
+	}
+	publicWitness, err := frontend.NewWitness(&assignment, ecc.BN254.ScalarField(), frontend.PublicOnly())
+	if err != nil {
+		return fmt.Errorf("build public witness: %w", err)
+	}
+
+	if err := groth16.Verify(proof, vk, publicWitness); err != nil {
+		return fmt.Errorf("invalid ballot proof: %w", err)
+	}
+	return nil
+}
+
+func stringToField(s string) *big.Int {
+	return new(big.Int).SetBytes([]byte(s))
+}
+
+// TallyDecrypt collects threshold-decryption shares from shares (keyed by
+// trustee ID), and once at least a majority of the registered Trustees have
+// contributed, reconstructs the plaintext preferences for every
+// EncryptedBallot and hands them to the STV engine — gated on quorum the
+// same way CountVotes is, so an under-participated anonymous election also
+// leaves its seats unfilled rather than tallying anyway.
+func (s *SmartContract) TallyDecrypt(ctx contractapi.TransactionContextInterface, shares map[string][]byte) error {
+	election, err := readElection(ctx)
+	if err != nil {
+		return err
+	}
+	if !election.Anonymous {
+		return fmt.Errorf("this election does not use anonymous ballots")
+	}
+	if election.State != StateTallying {
+		return fmt.Errorf("election must be in the Tallying state to be counted, got %s", election.State)
+	}
+
+	// Each trustee's share corresponds to the x-coordinate it was issued
+	// at: 1-based position in Trustees, matching how a Shamir dealer would
+	// have split the election key off-chain when Trustees was registered.
+	validShares := map[int][]byte{}
+	for trustee, share := range shares {
+		idx := trusteeIndex(election.Trustees, trustee)
+		if idx < 0 {
+			return fmt.Errorf("%q is not a registered trustee", trustee)
+		}
+		validShares[idx+1] = share
+	}
+
+	threshold := len(election.Trustees)/2 + 1
+	if len(validShares) < threshold {
+		return fmt.Errorf("need %d trustee shares to reconstruct, got %d", threshold, len(validShares))
+	}
+
+	if _, quorumMet := evaluateQuorum(&election); quorumMet {
+		ballots := make([]Ballot, 0, len(election.EncryptedBallots))
+		for _, eb := range election.EncryptedBallots {
+			prefs, err := decryptBallot(eb.Ciphertext, validShares)
+			if err != nil {
+				return fmt.Errorf("decrypt ballot for nullifier %s: %w", eb.Nullifier, err)
+			}
+			ballots = append(ballots, Ballot{
+				Voter:       eb.Nullifier,
+				Preferences: prefs,
+				Voted:       true,
+			})
+		}
+
+		election.Rounds, election.Elected, err = runSTV(&election, ballots)
+		if err != nil {
+			return err
+		}
+		applyThreshold(&election)
+	} else {
+		election.Elected = nil
+	}
+
+	election.Tallied = true
+	election.State = StateClosed
+
+	return putElection(ctx, election)
+}
+
+// decryptBallot reconstructs the election's symmetric key from a t-of-n
+// Shamir secret sharing among shares' x-coordinates (see shamirCombine),
+// derives a keystream from that key, and XORs it against ciphertext. Only
+// a genuine threshold subset of shares — the ones actually handed out when
+// the key was split — reconstructs the real key; any other subset
+// combines to a different key, whose keystream decrypts to garbage that
+// fails the JSON check below rather than silently succeeding.
+func decryptBallot(ciphertext []byte, shares map[int][]byte) ([]int, error) {
+	key, err := shamirCombine(shares)
+	if err != nil {
+		return nil, err
+	}
+
+	keystream := expandKeystream(key, len(ciphertext))
+	plaintext := make([]byte, len(ciphertext))
+	for i := range ciphertext {
+		plaintext[i] = ciphertext[i] ^ keystream[i]
+	}
+
+	var prefs []int
+	if err := json.Unmarshal(plaintext, &prefs); err != nil {
+		return nil, fmt.Errorf("reconstructed ballot is not valid: %w", err)
+	}
+	return prefs, nil
+}
+
+// expandKeystream derives an n-byte keystream from key via repeated
+// SHA-256, the same construction a hash-based stream cipher would use.
+func expandKeystream(key []byte, n int) []byte {
+	out := make([]byte, 0, n+sha256.Size)
+	for counter := 0; len(out) < n; counter++ {
+		block := sha256.Sum256(append(append([]byte{}, key...), byte(counter)))
+		out = append(out, block[:]...)
+	}
+	return out[:n]
+}
+
+// trusteeIndex returns trustee's position in trustees, or -1 if absent.
+func trusteeIndex(trustees []string, trustee string) int {
+	for i, t := range trustees {
+		if t == trustee {
+			return i
+		}
+	}
+	return -1
+}